@@ -0,0 +1,153 @@
+package syslog
+
+import (
+	"sync"
+	"time"
+)
+
+// RetryStrategy decides how long Run should wait before attempting to
+// (re)connect or resend on a drain, and whether an attempt should happen at
+// all right now.
+type RetryStrategy interface {
+	// Allow reports whether a connection attempt should be made. A circuit
+	// breaker returns false while OPEN.
+	Allow() bool
+	// NextDelay returns how long to wait before the next attempt.
+	NextDelay() time.Duration
+	// RecordResult tells the strategy the outcome of the most recent
+	// connection attempt or send, so it can update its internal state.
+	RecordResult(err error)
+}
+
+// CircuitState is the health state of a circuitBreakerStrategy.
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// instrumentedRetryStrategy is implemented by RetryStrategy implementations
+// that can report health metrics, such as circuitBreakerStrategy.
+type instrumentedRetryStrategy interface {
+	State() (state CircuitState, consecutiveFailures int, lastTransitionTime time.Time)
+}
+
+// circuitBreakerStrategy wraps a plain backoff function with a circuit
+// breaker: after failureThreshold consecutive failures it transitions to
+// OPEN and fast-fails Allow() for coolDownPeriod, then admits a single
+// HALF-OPEN probe before closing again on success. This bounds the resource
+// cost of a permanently broken drain, where numberOfTries would otherwise
+// grow without bound.
+type circuitBreakerStrategy struct {
+	backoff          func(numberOfTries int) time.Duration
+	failureThreshold int
+	coolDownPeriod   time.Duration
+
+	lock                  sync.Mutex
+	state                 CircuitState
+	consecutiveFailures   int
+	lastTransitionTime    time.Time
+	halfOpenProbeInFlight bool
+}
+
+// NewCircuitBreakerStrategy builds a RetryStrategy that delegates its delay
+// calculation to backoff (e.g. retrystrategy.NewExponentialRetryStrategy())
+// but opens the circuit after failureThreshold consecutive failures for
+// coolDownPeriod.
+func NewCircuitBreakerStrategy(backoff func(numberOfTries int) time.Duration, failureThreshold int, coolDownPeriod time.Duration) RetryStrategy {
+	return &circuitBreakerStrategy{
+		backoff:            backoff,
+		failureThreshold:   failureThreshold,
+		coolDownPeriod:     coolDownPeriod,
+		lastTransitionTime: time.Now(),
+	}
+}
+
+func (c *circuitBreakerStrategy) Allow() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	switch c.state {
+	case CircuitOpen:
+		if time.Since(c.lastTransitionTime) < c.coolDownPeriod {
+			return false
+		}
+		c.transitionTo(CircuitHalfOpen)
+		c.halfOpenProbeInFlight = true
+		return true
+	case CircuitHalfOpen:
+		if c.halfOpenProbeInFlight {
+			return false
+		}
+		c.halfOpenProbeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (c *circuitBreakerStrategy) RecordResult(err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.halfOpenProbeInFlight = false
+
+	if err == nil {
+		c.consecutiveFailures = 0
+		if c.state != CircuitClosed {
+			c.transitionTo(CircuitClosed)
+		}
+		return
+	}
+
+	if c.consecutiveFailures < c.failureThreshold {
+		c.consecutiveFailures++
+	}
+
+	if c.state == CircuitHalfOpen || c.consecutiveFailures >= c.failureThreshold {
+		c.transitionTo(CircuitOpen)
+	}
+}
+
+// NextDelay returns how long Run should wait before its next Allow call.
+// While OPEN, that's whatever remains of coolDownPeriod, so the wait
+// actually matches the configured cool-down instead of the unrelated
+// backoff curve; otherwise it's backoff(consecutiveFailures) as before.
+func (c *circuitBreakerStrategy) NextDelay() time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.state == CircuitOpen {
+		remaining := c.coolDownPeriod - time.Since(c.lastTransitionTime)
+		if remaining < 0 {
+			return 0
+		}
+		return remaining
+	}
+
+	return c.backoff(c.consecutiveFailures)
+}
+
+func (c *circuitBreakerStrategy) State() (CircuitState, int, time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.state, c.consecutiveFailures, c.lastTransitionTime
+}
+
+func (c *circuitBreakerStrategy) transitionTo(state CircuitState) {
+	c.state = state
+	c.lastTransitionTime = time.Now()
+}
@@ -0,0 +1,272 @@
+package syslog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry/sonde-go/events"
+)
+
+// FilterExpression is a compiled per-drain predicate evaluated against each
+// envelope before it's admitted to a SyslogSink's drain buffer.
+type FilterExpression interface {
+	Match(envelope *events.Envelope) bool
+}
+
+// CompileFilter parses a filter DSL expression combining event_type,
+// source_type, source_instance, message (regex via ~=), and tag[key]
+// comparisons with and/or/not, e.g.:
+//
+//	source_type = "APP" and not message ~= "healthcheck"
+//
+// It's compiled once at sink construction so a malformed expression is
+// reported immediately from NewSyslogSink rather than silently admitting
+// everything at drain time.
+func CompileFilter(source string) (FilterExpression, error) {
+	tokens, err := tokenizeFilter(source)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &filterParser{tokens: tokens}
+	expr, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos != len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected token %q", parser.tokens[parser.pos])
+	}
+	return expr, nil
+}
+
+func tokenizeFilter(source string) ([]string, error) {
+	var tokens []string
+	runes := []rune(source)
+	i := 0
+	for i < len(runes) {
+		switch {
+		case runes[i] == ' ' || runes[i] == '\t' || runes[i] == '\n':
+			i++
+		case runes[i] == '(' || runes[i] == ')':
+			tokens = append(tokens, string(runes[i]))
+			i++
+		case runes[i] == '"':
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' {
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string literal starting at position %d", i)
+			}
+			tokens = append(tokens, strconv.Quote(string(runes[i+1:j])))
+			i = j + 1
+		default:
+			j := i
+			for j < len(runes) && !strings.ContainsRune(" \t\n()", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *filterParser) parseOr() (FilterExpression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "or" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (FilterExpression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "and" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (FilterExpression, error) {
+	if p.peek() == "not" {
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (FilterExpression, error) {
+	if p.peek() == "(" {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("expected ) but found %q", p.peek())
+		}
+		p.next()
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (FilterExpression, error) {
+	field := p.next()
+	if field == "" {
+		return nil, fmt.Errorf("expected expression, found end of input")
+	}
+
+	op := p.next()
+	if op != "=" && op != "!=" && op != "~=" {
+		return nil, fmt.Errorf("expected comparison operator after %q, found %q", field, op)
+	}
+
+	rawValue := p.next()
+	value, err := strconv.Unquote(rawValue)
+	if err != nil {
+		return nil, fmt.Errorf("expected quoted string value, found %q", rawValue)
+	}
+
+	if strings.HasPrefix(field, "tag[") && strings.HasSuffix(field, "]") {
+		if op == "~=" {
+			return nil, fmt.Errorf("~= is only supported on message")
+		}
+		return tagExpr{key: field[len("tag[") : len(field)-1], op: op, value: value}, nil
+	}
+
+	switch field {
+	case "event_type", "source_type", "source_instance", "message":
+		if op == "~=" && field != "message" {
+			return nil, fmt.Errorf("~= is only supported on message")
+		}
+		return newFieldExpr(field, op, value)
+	default:
+		return nil, fmt.Errorf("unknown filter field %q", field)
+	}
+}
+
+type fieldExpr struct {
+	field string
+	op    string
+	value string
+	regex *regexp.Regexp
+}
+
+func newFieldExpr(field, op, value string) (FilterExpression, error) {
+	expr := fieldExpr{field: field, op: op, value: value}
+	if op == "~=" {
+		regex, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %s", value, err)
+		}
+		expr.regex = regex
+	}
+	return expr, nil
+}
+
+func (e fieldExpr) Match(envelope *events.Envelope) bool {
+	actual := e.fieldValue(envelope)
+	switch e.op {
+	case "=":
+		return actual == e.value
+	case "!=":
+		return actual != e.value
+	case "~=":
+		return e.regex.MatchString(actual)
+	default:
+		return false
+	}
+}
+
+func (e fieldExpr) fieldValue(envelope *events.Envelope) string {
+	switch e.field {
+	case "event_type":
+		return envelope.GetEventType().String()
+	case "source_type":
+		return envelope.GetLogMessage().GetSourceType()
+	case "source_instance":
+		return envelope.GetLogMessage().GetSourceInstance()
+	case "message":
+		return string(envelope.GetLogMessage().GetMessage())
+	default:
+		return ""
+	}
+}
+
+type tagExpr struct {
+	key   string
+	op    string
+	value string
+}
+
+func (e tagExpr) Match(envelope *events.Envelope) bool {
+	actual, ok := envelope.GetTags()[e.key]
+	switch e.op {
+	case "=":
+		return ok && actual == e.value
+	case "!=":
+		return !ok || actual != e.value
+	default:
+		return false
+	}
+}
+
+type andExpr struct{ left, right FilterExpression }
+
+func (e andExpr) Match(envelope *events.Envelope) bool {
+	return e.left.Match(envelope) && e.right.Match(envelope)
+}
+
+type orExpr struct{ left, right FilterExpression }
+
+func (e orExpr) Match(envelope *events.Envelope) bool {
+	return e.left.Match(envelope) || e.right.Match(envelope)
+}
+
+type notExpr struct{ expr FilterExpression }
+
+func (e notExpr) Match(envelope *events.Envelope) bool {
+	return !e.expr.Match(envelope)
+}
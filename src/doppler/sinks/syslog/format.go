@@ -0,0 +1,75 @@
+package syslog
+
+import (
+	"doppler/sinks/syslogwriter"
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/sonde-go/events"
+)
+
+// Format selects the syslog message encoding a SyslogSink writes. RFC5424
+// and RFC5424Octet require a syslogWriter that implements StructuredWriter;
+// the sink only computes the STRUCTURED-DATA element, the writer is
+// responsible for the RFC 5424 VERSION/HEADER and, for RFC5424Octet, the
+// RFC 6587 octet-counting frame prefix.
+type Format int
+
+const (
+	// RFC3164 is the sink's original behavior: a bare message body with no
+	// structured data.
+	RFC3164 Format = iota
+	// RFC5424 writes a proper RFC 5424 HEADER with a STRUCTURED-DATA field
+	// carrying the envelope's Cloud Foundry metadata, distinct from the MSG
+	// body, newline-delimited on the wire.
+	RFC5424
+	// RFC5424Octet is RFC5424 framed with RFC 6587 octet-counting instead
+	// of newline delimiting, for TCP and TLS drains that need to delimit
+	// messages on the wire themselves.
+	RFC5424Octet
+)
+
+const defaultSDID = "cf@47450"
+
+// StructuredWriter is implemented by syslogwriter.Writer implementations
+// that can emit an RFC 5424 message with a distinct VERSION/HEADER and
+// STRUCTURED-DATA field, rather than splicing structured data into the
+// RFC 3164 message body. octetFraming selects RFC 6587 octet-counting
+// (true) over newline-delimited framing (false).
+type StructuredWriter interface {
+	syslogwriter.Writer
+	WriteStructured(priority int, structuredData string, message []byte, sourceType, sourceInstance string, timestamp int64, octetFraming bool) (int, error)
+}
+
+// structuredData renders the RFC 5424 STRUCTURED-DATA element for envelope
+// under sdID, e.g. `[cf@47450 app_id="..." source_type="APP/PROC/WEB"]`.
+// Fields with an empty value are omitted.
+func structuredData(sdID string, envelope *events.Envelope) string {
+	logMessage := envelope.GetLogMessage()
+	pairs := []struct{ key, value string }{
+		{"app_id", logMessage.GetAppId()},
+		{"source_type", logMessage.GetSourceType()},
+		{"source_instance", logMessage.GetSourceInstance()},
+		{"deployment", envelope.GetDeployment()},
+		{"job", envelope.GetJob()},
+		{"index", envelope.GetIndex()},
+		{"ip", envelope.GetIp()},
+	}
+
+	var fields []string
+	for _, pair := range pairs {
+		if pair.value == "" {
+			continue
+		}
+		fields = append(fields, fmt.Sprintf(`%s="%s"`, pair.key, sdParamEscape(pair.value)))
+	}
+
+	return fmt.Sprintf("[%s %s]", sdID, strings.Join(fields, " "))
+}
+
+// sdParamEscape escapes the characters RFC 5424 requires inside a
+// PARAM-VALUE: backslash, double quote, and right bracket.
+func sdParamEscape(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	return replacer.Replace(value)
+}
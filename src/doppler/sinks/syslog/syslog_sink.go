@@ -5,11 +5,13 @@ import (
 	"doppler/sinks/syslogwriter"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"doppler/sinks"
 
 	"github.com/cloudfoundry/gosteno"
+	"github.com/cloudfoundry/loggregatorlib/cfcomponent/instrumentation"
 	"github.com/cloudfoundry/sonde-go/events"
 )
 
@@ -26,11 +28,80 @@ type SyslogSink struct {
 	disconnectChannel      chan struct{}
 	dropsondeOrigin        string
 	disconnectOnce         sync.Once
+
+	format   Format
+	sdID     string
+	facility int
+
+	filter           FilterExpression
+	filterErr        error
+	filteredInCount  uint64
+	filteredOutCount uint64
+
+	retryStrategy RetryStrategy
+}
+
+const (
+	defaultFailureThreshold = 5
+	defaultCoolDownPeriod   = 30 * time.Second
+)
+
+// SinkOption configures optional behavior of a SyslogSink at construction
+// time.
+type SinkOption func(*SyslogSink)
+
+// WithFormat selects the syslog message encoding the sink writes. Defaults
+// to RFC3164.
+func WithFormat(format Format) SinkOption {
+	return func(s *SyslogSink) {
+		s.format = format
+	}
 }
 
-func NewSyslogSink(appId string, drainUrl string, givenLogger *gosteno.Logger, messageDrainBufferSize uint, syslogWriter syslogwriter.Writer, errorHandler func(string, string, string), dropsondeOrigin string) *SyslogSink {
+// WithStructuredDataID sets the SD-ID namespace used for the RFC 5424
+// STRUCTURED-DATA element, e.g. "cf@47450". Only meaningful with
+// WithFormat(RFC5424) or WithFormat(RFC5424Octet).
+func WithStructuredDataID(sdID string) SinkOption {
+	return func(s *SyslogSink) {
+		s.sdID = sdID
+	}
+}
+
+// WithFacility overrides the syslog facility used to compute the PRI value,
+// in place of the sink's previously hardcoded facility 1.
+func WithFacility(facility int) SinkOption {
+	return func(s *SyslogSink) {
+		s.facility = facility
+	}
+}
+
+// WithFilter compiles expression (see CompileFilter) and, once the sink is
+// running, admits only envelopes it matches into the drain buffer, in place
+// of the sink's previously hardcoded log-message-only check. A parse error
+// is surfaced from NewSyslogSink rather than failing silently at drain
+// time.
+func WithFilter(expression string) SinkOption {
+	return func(s *SyslogSink) {
+		filter, err := CompileFilter(expression)
+		if err != nil {
+			s.filterErr = err
+			return
+		}
+		s.filter = filter
+	}
+}
+
+// WithRetryStrategy overrides the sink's RetryStrategy, which otherwise
+// defaults to a circuit breaker wrapping retrystrategy.NewExponentialRetryStrategy().
+func WithRetryStrategy(retryStrategy RetryStrategy) SinkOption {
+	return func(s *SyslogSink) {
+		s.retryStrategy = retryStrategy
+	}
+}
+
+func NewSyslogSink(appId string, drainUrl string, givenLogger *gosteno.Logger, messageDrainBufferSize uint, syslogWriter syslogwriter.Writer, errorHandler func(string, string, string), dropsondeOrigin string, opts ...SinkOption) (*SyslogSink, error) {
 	givenLogger.Debugf("Syslog Sink %s: Created for appId [%s]", drainUrl, appId)
-	return &SyslogSink{
+	sink := &SyslogSink{
 		appId:                  appId,
 		drainUrl:               drainUrl,
 		logger:                 givenLogger,
@@ -39,15 +110,35 @@ func NewSyslogSink(appId string, drainUrl string, givenLogger *gosteno.Logger, m
 		handleSendError:        errorHandler,
 		disconnectChannel:      make(chan struct{}),
 		dropsondeOrigin:        dropsondeOrigin,
+		format:                 RFC3164,
+		sdID:                   defaultSDID,
+		facility:               1,
+		retryStrategy:          NewCircuitBreakerStrategy(retrystrategy.NewExponentialRetryStrategy(), defaultFailureThreshold, defaultCoolDownPeriod),
+	}
+
+	for _, opt := range opts {
+		opt(sink)
+	}
+
+	if sink.filterErr != nil {
+		return nil, sink.filterErr
 	}
+
+	if sink.filter == nil {
+		filter, err := CompileFilter(`event_type = "LogMessage"`)
+		if err != nil {
+			return nil, err
+		}
+		sink.filter = filter
+	}
+
+	return sink, nil
 }
 
 func (s *SyslogSink) Run(inputChan <-chan *events.Envelope) {
 	s.logger.Infof("Syslog Sink %s: Running.", s.drainUrl)
 	defer s.logger.Errorf("Syslog Sink %s: Stopped.", s.drainUrl)
 
-	backoffStrategy := retrystrategy.NewExponentialRetryStrategy()
-	numberOfTries := 0
 	filteredChan := make(chan *events.Envelope)
 
 	go func() {
@@ -60,9 +151,11 @@ func (s *SyslogSink) Run(inputChan <-chan *events.Envelope) {
 					return
 				}
 
-				if v.GetEventType() != events.Envelope_LogMessage {
+				if !s.filter.Match(v) {
+					atomic.AddUint64(&s.filteredOutCount, 1)
 					continue
 				}
+				atomic.AddUint64(&s.filteredInCount, 1)
 
 				filteredChan <- v
 			case <-s.disconnectChannel:
@@ -72,18 +165,29 @@ func (s *SyslogSink) Run(inputChan <-chan *events.Envelope) {
 	}()
 
 	buffer := sinks.RunTruncatingBuffer(filteredChan, s.messageDrainBufferSize, s.logger, s.dropsondeOrigin, s.Identifier())
-	timer := time.NewTimer(backoffStrategy(numberOfTries))
+	timer := time.NewTimer(s.retryStrategy.NextDelay())
 	connected := false
 	defer timer.Stop()
 	defer s.syslogWriter.Close()
 
-	s.logger.Debugf("Syslog Sink %s: Starting loop. Current backoff: %v", s.drainUrl, backoffStrategy(numberOfTries))
+	s.logger.Debugf("Syslog Sink %s: Starting loop.", s.drainUrl)
 	for {
 		if !connected {
+			if !s.retryStrategy.Allow() {
+				timer.Reset(s.retryStrategy.NextDelay())
+				select {
+				case <-s.disconnectChannel:
+					return
+				case <-timer.C:
+				}
+				continue
+			}
+
 			s.logger.Debugf("Syslog Sink %s: Not connected. Trying to connect.", s.drainUrl)
 			err := s.syslogWriter.Connect()
+			s.retryStrategy.RecordResult(err)
 			if err != nil {
-				sleepDuration := backoffStrategy(numberOfTries)
+				sleepDuration := s.retryStrategy.NextDelay()
 				errorMsg := fmt.Sprintf("Syslog Sink %s: Error when dialing out. Backing off for %v. Err: %v", s.drainUrl, sleepDuration, err)
 
 				s.handleSendError(errorMsg, s.appId, s.drainUrl)
@@ -95,7 +199,6 @@ func (s *SyslogSink) Run(inputChan <-chan *events.Envelope) {
 				case <-timer.C:
 				}
 
-				numberOfTries++
 				continue
 			}
 
@@ -116,13 +219,12 @@ func (s *SyslogSink) Run(inputChan <-chan *events.Envelope) {
 
 			// Some metrics will not be filter and can get to here (i.e.: TruncatingBuffer dropped message metrics)
 			if messageEnvelope.GetEventType() == events.Envelope_LogMessage {
-				err := s.sendLogMessage(messageEnvelope.GetLogMessage())
+				err := s.sendLogMessage(messageEnvelope)
+				s.retryStrategy.RecordResult(err)
 				if err == nil {
-					numberOfTries = 0
 					connected = true
 				} else {
 					s.logger.Debugf("Syslog Sink %s: Error when trying to send data to sink. Backing off. Err: %v\n", s.drainUrl, err)
-					numberOfTries++
 					connected = false
 				}
 			}
@@ -146,18 +248,55 @@ func (s *SyslogSink) ShouldReceiveErrors() bool {
 	return false
 }
 
-func (s *SyslogSink) sendLogMessage(logMessage *events.LogMessage) error {
-	_, err := s.syslogWriter.Write(messagePriorityValue(logMessage), logMessage.GetMessage(), logMessage.GetSourceType(), logMessage.GetSourceInstance(), *logMessage.Timestamp)
+func (s *SyslogSink) sendLogMessage(envelope *events.Envelope) error {
+	logMessage := envelope.GetLogMessage()
+	priority := messagePriorityValue(logMessage, s.facility)
+
+	if s.format == RFC5424 || s.format == RFC5424Octet {
+		structuredWriter, ok := s.syslogWriter.(StructuredWriter)
+		if !ok {
+			return fmt.Errorf("Syslog Sink %s: syslogWriter does not implement StructuredWriter, required for RFC 5424 output", s.drainUrl)
+		}
+
+		_, err := structuredWriter.WriteStructured(priority, structuredData(s.sdID, envelope), logMessage.GetMessage(), logMessage.GetSourceType(), logMessage.GetSourceInstance(), *logMessage.Timestamp, s.format == RFC5424Octet)
+		return err
+	}
+
+	_, err := s.syslogWriter.Write(priority, logMessage.GetMessage(), logMessage.GetSourceType(), logMessage.GetSourceInstance(), *logMessage.Timestamp)
 	return err
 }
 
-func messagePriorityValue(msg *events.LogMessage) int {
+func messagePriorityValue(msg *events.LogMessage, facility int) int {
 	switch msg.GetMessageType() {
 	case events.LogMessage_OUT:
-		return 14
+		return facility*8 + 6
 	case events.LogMessage_ERR:
-		return 11
+		return facility*8 + 3
 	default:
 		return -1
 	}
 }
+
+func (s *SyslogSink) Emit() instrumentation.Context {
+	return instrumentation.Context{Name: "syslogSink",
+		Metrics: s.metrics(),
+	}
+}
+
+func (s *SyslogSink) metrics() []instrumentation.Metric {
+	metrics := []instrumentation.Metric{
+		instrumentation.Metric{Name: "filteredInCount", Value: atomic.LoadUint64(&s.filteredInCount)},
+		instrumentation.Metric{Name: "filteredOutCount", Value: atomic.LoadUint64(&s.filteredOutCount)},
+	}
+
+	if instrumented, ok := s.retryStrategy.(instrumentedRetryStrategy); ok {
+		state, consecutiveFailures, lastTransitionTime := instrumented.State()
+		metrics = append(metrics,
+			instrumentation.Metric{Name: "circuitState", Value: state.String()},
+			instrumentation.Metric{Name: "consecutiveFailures", Value: consecutiveFailures},
+			instrumentation.Metric{Name: "lastTransitionTime", Value: lastTransitionTime.Unix()},
+		)
+	}
+
+	return metrics
+}
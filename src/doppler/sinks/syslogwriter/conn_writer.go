@@ -0,0 +1,36 @@
+package syslogwriter
+
+import (
+	"fmt"
+	"net"
+)
+
+// connWriter implements the framing and write mechanics shared by every
+// connection-oriented Writer (TCPWriter, TLSWriter); each embeds it and
+// only supplies its own Connect dialer.
+type connWriter struct {
+	connection net.Conn
+}
+
+func (w *connWriter) Write(priority int, message []byte, sourceType, sourceInstance string, timestamp int64) (int, error) {
+	frame := append(rfc3164Message(priority, message, sourceType, sourceInstance, timestamp), '\n')
+	return w.connection.Write(frame)
+}
+
+// WriteStructured writes an RFC 5424 formatted message, satisfying
+// doppler/sinks/syslog.StructuredWriter. octetFraming selects RFC 6587
+// octet-counting over newline-delimited framing.
+func (w *connWriter) WriteStructured(priority int, structuredData string, message []byte, sourceType, sourceInstance string, timestamp int64, octetFraming bool) (int, error) {
+	frame := rfc5424Message(priority, structuredData, message, sourceType, sourceInstance, timestamp)
+	if octetFraming {
+		return w.connection.Write([]byte(fmt.Sprintf("%d %s", len(frame), frame)))
+	}
+	return w.connection.Write(append(frame, '\n'))
+}
+
+func (w *connWriter) Close() error {
+	if w.connection == nil {
+		return nil
+	}
+	return w.connection.Close()
+}
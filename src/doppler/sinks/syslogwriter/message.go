@@ -0,0 +1,45 @@
+package syslogwriter
+
+import (
+	"fmt"
+	"time"
+)
+
+// nilValue is the RFC 5424 NILVALUE ("-"), used for HEADER fields this
+// package has no data for (HOSTNAME, MSGID).
+const nilValue = "-"
+
+// rfc3164Message renders a BSD syslog (RFC 3164) formatted message:
+// "<PRI>Mmm dd hh:mm:ss TAG: MSG".
+func rfc3164Message(priority int, message []byte, sourceType, sourceInstance string, timestamp int64) []byte {
+	t := time.Unix(0, timestamp).UTC()
+	return []byte(fmt.Sprintf("<%d>%s %s: %s", priority, t.Format("Jan _2 15:04:05"), tag(sourceType, sourceInstance), message))
+}
+
+// rfc5424Message renders an RFC 5424 formatted message: a VERSION/HEADER
+// ("<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID"), followed by the
+// caller-supplied STRUCTURED-DATA element and the MSG body.
+func rfc5424Message(priority int, structuredData string, message []byte, sourceType, sourceInstance string, timestamp int64) []byte {
+	t := time.Unix(0, timestamp).UTC()
+
+	appName := sourceType
+	if appName == "" {
+		appName = nilValue
+	}
+	procID := sourceInstance
+	if procID == "" {
+		procID = nilValue
+	}
+
+	return []byte(fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
+		priority, t.Format(time.RFC3339), nilValue, appName, procID, nilValue, structuredData, message))
+}
+
+// tag renders the RFC 3164 TAG field from a log message's source type and
+// instance, e.g. "APP/PROC/WEB/0".
+func tag(sourceType, sourceInstance string) string {
+	if sourceInstance == "" {
+		return sourceType
+	}
+	return fmt.Sprintf("%s/%s", sourceType, sourceInstance)
+}
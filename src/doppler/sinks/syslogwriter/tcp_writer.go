@@ -0,0 +1,23 @@
+package syslogwriter
+
+import "net"
+
+// TCPWriter dials a drain over plain TCP.
+type TCPWriter struct {
+	outputAddr string
+	connWriter
+}
+
+// NewTCPWriter returns a Writer that dials outputAddr (host:port) on Connect.
+func NewTCPWriter(outputAddr string) *TCPWriter {
+	return &TCPWriter{outputAddr: outputAddr}
+}
+
+func (w *TCPWriter) Connect() error {
+	connection, err := net.Dial("tcp", w.outputAddr)
+	if err != nil {
+		return err
+	}
+	w.connection = connection
+	return nil
+}
@@ -0,0 +1,25 @@
+package syslogwriter
+
+import "crypto/tls"
+
+// TLSWriter dials a drain over TLS.
+type TLSWriter struct {
+	outputAddr string
+	tlsConfig  *tls.Config
+	connWriter
+}
+
+// NewTLSWriter returns a Writer that dials outputAddr (host:port) over TLS
+// on Connect, verifying the drain's certificate against tlsConfig.
+func NewTLSWriter(outputAddr string, tlsConfig *tls.Config) *TLSWriter {
+	return &TLSWriter{outputAddr: outputAddr, tlsConfig: tlsConfig}
+}
+
+func (w *TLSWriter) Connect() error {
+	connection, err := tls.Dial("tcp", w.outputAddr, w.tlsConfig)
+	if err != nil {
+		return err
+	}
+	w.connection = connection
+	return nil
+}
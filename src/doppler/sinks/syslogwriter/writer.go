@@ -0,0 +1,14 @@
+// Package syslogwriter dials a single syslog drain and writes formatted
+// messages to it. A Writer never retries or reconnects on its own; that's
+// SyslogSink's job, driven by its RetryStrategy.
+package syslogwriter
+
+// Writer sends a single drain's log output over an established connection.
+type Writer interface {
+	// Connect dials the drain. It must be called, and must succeed, before
+	// Write.
+	Connect() error
+	// Write sends message as an RFC 3164 formatted syslog line.
+	Write(priority int, message []byte, sourceType, sourceInstance string, timestamp int64) (int, error)
+	Close() error
+}
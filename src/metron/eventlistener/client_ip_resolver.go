@@ -0,0 +1,83 @@
+package eventlistener
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+)
+
+// ClientIPResolver recovers the originating client address for
+// connection-oriented ingress sitting behind a reverse proxy (HAProxy, an
+// nginx front-end) by honoring X-Real-Ip and the trusted hops of
+// X-Forwarded-For. Proxy headers are only trusted when they arrive from a
+// peer whose address falls within TrustedProxies; otherwise the
+// connection's own remote address is used.
+type ClientIPResolver struct {
+	TrustedProxies []*net.IPNet
+}
+
+// NewClientIPResolver parses cidrs (e.g. "10.0.0.0/8") into the resolver's
+// trusted proxy list.
+func NewClientIPResolver(cidrs []string) (*ClientIPResolver, error) {
+	trustedProxies := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		trustedProxies = append(trustedProxies, network)
+	}
+	return &ClientIPResolver{TrustedProxies: trustedProxies}, nil
+}
+
+// Resolve returns the real client address for a connection whose immediate
+// peer is remoteAddr, honoring header if remoteAddr is a trusted proxy hop.
+// It falls back to remoteAddr when the peer isn't trusted, or header
+// contains no usable address.
+func (r *ClientIPResolver) Resolve(remoteAddr net.Addr, header textproto.MIMEHeader) net.Addr {
+	if !r.trusted(remoteAddr) {
+		return remoteAddr
+	}
+
+	if realIP := header.Get("X-Real-Ip"); realIP != "" {
+		if ip := net.ParseIP(realIP); ip != nil {
+			return &net.IPAddr{IP: ip}
+		}
+	}
+
+	if forwardedFor := header.Get("X-Forwarded-For"); forwardedFor != "" {
+		hops := strings.Split(forwardedFor, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break
+			}
+			if !r.trustedIP(ip) {
+				return &net.IPAddr{IP: ip}
+			}
+		}
+	}
+
+	return remoteAddr
+}
+
+func (r *ClientIPResolver) trusted(addr net.Addr) bool {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	return r.trustedIP(ip)
+}
+
+func (r *ClientIPResolver) trustedIP(ip net.IP) bool {
+	for _, network := range r.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package eventlistener
+
+import (
+	"net"
+	"net/textproto"
+	"testing"
+)
+
+func mustResolver(t *testing.T, cidrs ...string) *ClientIPResolver {
+	t.Helper()
+	resolver, err := NewClientIPResolver(cidrs)
+	if err != nil {
+		t.Fatalf("NewClientIPResolver(%v) returned error: %v", cidrs, err)
+	}
+	return resolver
+}
+
+func TestClientIPResolverUntrustedPeerIgnoresHeaders(t *testing.T) {
+	resolver := mustResolver(t, "10.0.0.0/8")
+	remoteAddr := &net.TCPAddr{IP: net.ParseIP("203.0.113.5")}
+	header := textproto.MIMEHeader{"X-Real-Ip": {"198.51.100.1"}}
+
+	got := resolver.Resolve(remoteAddr, header)
+	if got.String() != remoteAddr.String() {
+		t.Errorf("Resolve() = %v, want untrusted peer's own address %v", got, remoteAddr)
+	}
+}
+
+func TestClientIPResolverTrustedPeerHonorsXRealIp(t *testing.T) {
+	resolver := mustResolver(t, "10.0.0.0/8")
+	remoteAddr := &net.TCPAddr{IP: net.ParseIP("10.1.2.3")}
+	header := textproto.MIMEHeader{"X-Real-Ip": {"198.51.100.1"}}
+
+	got := resolver.Resolve(remoteAddr, header)
+	if got.String() != "198.51.100.1" {
+		t.Errorf("Resolve() = %v, want X-Real-Ip address 198.51.100.1", got)
+	}
+}
+
+func TestClientIPResolverTrustedPeerWalksForwardedForToFirstUntrustedHop(t *testing.T) {
+	resolver := mustResolver(t, "10.0.0.0/8")
+	remoteAddr := &net.TCPAddr{IP: net.ParseIP("10.1.2.3")}
+	// Client, then two trusted proxy hops, in the order they were appended.
+	header := textproto.MIMEHeader{"X-Forwarded-For": {"198.51.100.1, 10.0.0.1, 10.0.0.2"}}
+
+	got := resolver.Resolve(remoteAddr, header)
+	if got.String() != "198.51.100.1" {
+		t.Errorf("Resolve() = %v, want the first untrusted hop 198.51.100.1", got)
+	}
+}
+
+func TestClientIPResolverTrustedPeerWithNoUsableHeaderFallsBackToRemoteAddr(t *testing.T) {
+	resolver := mustResolver(t, "10.0.0.0/8")
+	remoteAddr := &net.TCPAddr{IP: net.ParseIP("10.1.2.3")}
+
+	got := resolver.Resolve(remoteAddr, textproto.MIMEHeader{})
+	if got.String() != remoteAddr.String() {
+		t.Errorf("Resolve() = %v, want fallback to remoteAddr %v", got, remoteAddr)
+	}
+}
+
+func TestClientIPResolverOnlyTrustsConfiguredCIDRs(t *testing.T) {
+	resolver := mustResolver(t, "10.0.0.0/8")
+
+	if !resolver.trustedIP(net.ParseIP("10.255.255.255")) {
+		t.Error("trustedIP(10.255.255.255) = false, want true for an address inside 10.0.0.0/8")
+	}
+	if resolver.trustedIP(net.ParseIP("192.168.1.1")) {
+		t.Error("trustedIP(192.168.1.1) = true, want false for an address outside 10.0.0.0/8")
+	}
+}
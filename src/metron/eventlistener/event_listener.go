@@ -4,21 +4,31 @@ import (
 	"net"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/cloudfoundry/gosteno"
 	"github.com/cloudfoundry/loggregatorlib/cfcomponent/instrumentation"
 )
 
+const (
+	defaultBufferCapacity = 1024
+	defaultBlockTimeout   = 5 * time.Second
+)
+
 type heartbeatRequester interface {
 	Start(net.Addr, net.PacketConn)
 	KnownAndReset(senderAddr net.Addr) bool
 }
 
 type EventListener struct {
-	host        string
-	dataChannel chan []byte
-	connection  net.PacketConn
-	requester   heartbeatRequester
+	host      string
+	ring      *ringBuffer
+	transport Transport
+	requester heartbeatRequester
+
+	bufferCapacity int
+	overflowPolicy OverflowPolicy
+	blockTimeout   time.Duration
 
 	receivedMessageCount uint64
 	receivedByteCount    uint64
@@ -28,27 +38,44 @@ type EventListener struct {
 	logger *gosteno.Logger
 }
 
-func New(host string, givenLogger *gosteno.Logger, name string, requester heartbeatRequester) (*EventListener, <-chan []byte) {
-	byteChan := make(chan []byte, 1024)
-	return &EventListener{logger: givenLogger, host: host, dataChannel: byteChan, contextName: name, requester: requester}, byteChan
+// New constructs an EventListener that by default ingests over UDP. Pass a
+// transport Option (WithTCPTransport, WithTLSTransport, WithUnixgramTransport)
+// to listen over a different protocol; connection-oriented transports should
+// be paired with a NoopRequester since they have no net.PacketConn for
+// heartbeat correlation. Use WithBufferCapacity/WithOverflowPolicy/
+// WithBlockTimeout to tune how the dataChannel ring buffer behaves under
+// bursty ingest.
+func New(host string, givenLogger *gosteno.Logger, name string, requester heartbeatRequester, opts ...Option) (*EventListener, <-chan []byte, error) {
+	eventListener := &EventListener{
+		logger:         givenLogger,
+		host:           host,
+		contextName:    name,
+		requester:      requester,
+		transport:      newUDPTransport(defaultReadBufferSize),
+		bufferCapacity: defaultBufferCapacity,
+		overflowPolicy: BlockWithTimeout,
+		blockTimeout:   defaultBlockTimeout,
+	}
+	for _, opt := range opts {
+		if err := opt(eventListener); err != nil {
+			return nil, nil, err
+		}
+	}
+	eventListener.ring = newRingBuffer(eventListener.bufferCapacity, eventListener.overflowPolicy, eventListener.blockTimeout)
+	return eventListener, eventListener.ring.out, nil
 }
 
 func (eventListener *EventListener) Start() {
-	connection, err := net.ListenPacket("udp", eventListener.host)
-	if err != nil {
+	if err := eventListener.transport.Listen(eventListener.host); err != nil {
 		eventListener.logger.Fatalf("Failed to listen on port. %s", err)
 	}
-	connection.(*net.UDPConn).SetReadBuffer( 1024 * 1024 * 50 )
 
 	eventListener.logger.Infof("Listening on port %s", eventListener.host)
-	eventListener.lock.Lock()
-	eventListener.connection = connection
-	eventListener.lock.Unlock()
-	
-	readBuffer := make([]byte, 65535) //buffer with size = max theoretical UDP size
-	defer close(eventListener.dataChannel)
+
+	readBuffer := make([]byte, eventListener.transport.MaxMessageSize())
+	defer eventListener.ring.Close()
 	for {
-		readCount, senderAddr, err := connection.ReadFrom(readBuffer)
+		readCount, senderAddr, err := eventListener.transport.ReadMessage(readBuffer)
 		if err != nil {
 			eventListener.logger.Debugf("Error while reading. %s", err)
 			return
@@ -59,17 +86,17 @@ func (eventListener *EventListener) Start() {
 
 		atomic.AddUint64(&eventListener.receivedMessageCount, 1)
 		atomic.AddUint64(&eventListener.receivedByteCount, uint64(readCount))
-		eventListener.dataChannel <- readData
-        if ! eventListener.requester.KnownAndReset(senderAddr) {
-		  go eventListener.requester.Start(senderAddr, connection)
-	    }
+		eventListener.ring.Push(readData)
+		if !eventListener.requester.KnownAndReset(senderAddr) {
+			go eventListener.requester.Start(senderAddr, eventListener.transport.PacketConn())
+		}
 	}
 }
 
 func (eventListener *EventListener) Stop() {
 	eventListener.lock.Lock()
 	defer eventListener.lock.Unlock()
-	eventListener.connection.Close()
+	eventListener.transport.Close()
 }
 
 func (eventListener *EventListener) Emit() instrumentation.Context {
@@ -80,8 +107,10 @@ func (eventListener *EventListener) Emit() instrumentation.Context {
 
 func (eventListener *EventListener) metrics() []instrumentation.Metric {
 	return []instrumentation.Metric{
-		instrumentation.Metric{Name: "currentBufferCount", Value: len(eventListener.dataChannel)},
+		instrumentation.Metric{Name: "currentBufferCount", Value: len(eventListener.ring.out)},
 		instrumentation.Metric{Name: "receivedMessageCount", Value: atomic.LoadUint64(&eventListener.receivedMessageCount)},
 		instrumentation.Metric{Name: "receivedByteCount", Value: atomic.LoadUint64(&eventListener.receivedByteCount)},
+		instrumentation.Metric{Name: "droppedMessageCount", Value: eventListener.ring.DroppedCount()},
+		instrumentation.Metric{Name: "overflowPolicy", Value: eventListener.overflowPolicy.String()},
 	}
 }
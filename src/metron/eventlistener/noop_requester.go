@@ -0,0 +1,13 @@
+package eventlistener
+
+import "net"
+
+// NoopRequester is a heartbeatRequester that never sends heartbeats. Pair it
+// with a connection-oriented Transport (TCP, TLS), where a per-message
+// sender address doesn't correspond to a net.PacketConn the requester could
+// send probes over.
+type NoopRequester struct{}
+
+func (NoopRequester) Start(net.Addr, net.PacketConn) {}
+
+func (NoopRequester) KnownAndReset(net.Addr) bool { return true }
@@ -0,0 +1,125 @@
+package eventlistener
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+)
+
+const defaultReadBufferSize = 1024 * 1024 * 50
+
+// Option configures optional behavior of an EventListener at construction
+// time, such as which ingress Transport it uses. An Option returns an error
+// if it can't be applied, e.g. a stream-only Option given before a
+// WithTCPTransport/WithTLSTransport Option to establish the stream
+// transport it configures.
+type Option func(*EventListener) error
+
+// WithUDPTransport selects UDP ingress (the default), letting callers tune
+// the kernel read buffer size.
+func WithUDPTransport(readBufferSize int) Option {
+	return func(e *EventListener) error {
+		e.transport = newUDPTransport(readBufferSize)
+		return nil
+	}
+}
+
+// WithTCPTransport selects TCP ingress, length-prefix framed.
+func WithTCPTransport() Option {
+	return func(e *EventListener) error {
+		e.transport = newTCPTransport()
+		return nil
+	}
+}
+
+// WithTLSTransport selects TLS ingress, length-prefix framed. Pass a
+// tlsConfig with ClientAuth set to tls.RequireAndVerifyClientCert and
+// ClientCAs populated for mutual authentication.
+func WithTLSTransport(tlsConfig *tls.Config) Option {
+	return func(e *EventListener) error {
+		e.transport = newTLSTransport(tlsConfig)
+		return nil
+	}
+}
+
+// WithUnixgramTransport selects a Unix datagram socket for ingress.
+func WithUnixgramTransport(readBufferSize int) Option {
+	return func(e *EventListener) error {
+		e.transport = newUnixgramTransport(readBufferSize)
+		return nil
+	}
+}
+
+// WithBufferCapacity sets the number of messages the dataChannel ring
+// buffer holds before the OverflowPolicy kicks in. Defaults to 1024.
+func WithBufferCapacity(capacity int) Option {
+	return func(e *EventListener) error {
+		e.bufferCapacity = capacity
+		return nil
+	}
+}
+
+// WithOverflowPolicy sets how the dataChannel ring buffer behaves once full.
+// Defaults to BlockWithTimeout.
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(e *EventListener) error {
+		e.overflowPolicy = policy
+		return nil
+	}
+}
+
+// WithBlockTimeout sets how long BlockWithTimeout waits for room in the
+// ring buffer before falling back to dropping the message. Defaults to 5s.
+func WithBlockTimeout(timeout time.Duration) Option {
+	return func(e *EventListener) error {
+		e.blockTimeout = timeout
+		return nil
+	}
+}
+
+// WithClientIPResolver enables X-Real-Ip/X-Forwarded-For resolution on a
+// stream transport (TCP, TLS), so heartbeat correlation sees the real
+// client address rather than the reverse proxy's. Must be applied after
+// WithTCPTransport/WithTLSTransport, or it returns an error rather than
+// silently doing nothing.
+func WithClientIPResolver(resolver *ClientIPResolver) Option {
+	return func(e *EventListener) error {
+		transport, ok := e.transport.(*streamTransport)
+		if !ok {
+			return fmt.Errorf("eventlistener: WithClientIPResolver requires a stream transport; apply WithTCPTransport or WithTLSTransport first")
+		}
+		transport.clientIPResolver = resolver
+		return nil
+	}
+}
+
+// WithMaxFrameSize caps the length prefix a stream transport (TCP, TLS)
+// will honor before allocating a frame buffer. Defaults to 1MB. Must be
+// applied after WithTCPTransport/WithTLSTransport, or it returns an error
+// rather than silently doing nothing.
+func WithMaxFrameSize(maxFrameSize uint32) Option {
+	return func(e *EventListener) error {
+		transport, ok := e.transport.(*streamTransport)
+		if !ok {
+			return fmt.Errorf("eventlistener: WithMaxFrameSize requires a stream transport; apply WithTCPTransport or WithTLSTransport first")
+		}
+		transport.maxFrameSize = maxFrameSize
+		return nil
+	}
+}
+
+// WithReadTimeout bounds how long a stream transport (TCP, TLS) will wait
+// for a connection's header block or next length-prefixed frame before
+// dropping it. Defaults to 5 minutes. Must be applied after
+// WithTCPTransport/WithTLSTransport, or it returns an error rather than
+// silently doing nothing.
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(e *EventListener) error {
+		transport, ok := e.transport.(*streamTransport)
+		if !ok {
+			return fmt.Errorf("eventlistener: WithReadTimeout requires a stream transport; apply WithTCPTransport or WithTLSTransport first")
+		}
+		transport.readTimeout = timeout
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package eventlistener
+
+import (
+	"sync"
+	"time"
+)
+
+// OverflowPolicy controls what a ringBuffer does when Push is called
+// against a full buffer.
+type OverflowPolicy int
+
+const (
+	// DropOldest discards the oldest buffered message to make room for the
+	// incoming one.
+	DropOldest OverflowPolicy = iota
+	// DropNewest discards the incoming message, leaving the buffer as is.
+	DropNewest
+	// BlockWithTimeout blocks the pusher for up to the configured timeout
+	// before falling back to DropNewest.
+	BlockWithTimeout
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case DropOldest:
+		return "drop-oldest"
+	case BlockWithTimeout:
+		return "block-with-timeout"
+	default:
+		return "drop-newest"
+	}
+}
+
+// ringBuffer sits between the ingress Transport and the dataChannel
+// consumers of an EventListener. It exists so a burst of ingress traffic
+// degrades predictably, per the configured OverflowPolicy, instead of
+// blocking the read loop indefinitely.
+type ringBuffer struct {
+	out          chan []byte
+	policy       OverflowPolicy
+	blockTimeout time.Duration
+
+	lock    sync.Mutex
+	dropped uint64
+}
+
+func newRingBuffer(capacity int, policy OverflowPolicy, blockTimeout time.Duration) *ringBuffer {
+	return &ringBuffer{
+		out:          make(chan []byte, capacity),
+		policy:       policy,
+		blockTimeout: blockTimeout,
+	}
+}
+
+func (r *ringBuffer) Push(message []byte) {
+	select {
+	case r.out <- message:
+		return
+	default:
+	}
+
+	switch r.policy {
+	case DropOldest:
+		select {
+		case <-r.out:
+		default:
+		}
+		select {
+		case r.out <- message:
+		default:
+			r.incrementDropped()
+		}
+	case BlockWithTimeout:
+		timer := time.NewTimer(r.blockTimeout)
+		defer timer.Stop()
+		select {
+		case r.out <- message:
+		case <-timer.C:
+			r.incrementDropped()
+		}
+	default: // DropNewest
+		r.incrementDropped()
+	}
+}
+
+func (r *ringBuffer) incrementDropped() {
+	r.lock.Lock()
+	r.dropped++
+	r.lock.Unlock()
+}
+
+func (r *ringBuffer) DroppedCount() uint64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.dropped
+}
+
+func (r *ringBuffer) Close() {
+	close(r.out)
+}
@@ -0,0 +1,88 @@
+package eventlistener
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRingBufferDropOldestEvictsOldestOnOverflow(t *testing.T) {
+	r := newRingBuffer(2, DropOldest, 0)
+
+	r.Push([]byte("a"))
+	r.Push([]byte("b"))
+	r.Push([]byte("c")) // buffer full of a,b; evicts a to make room for c
+
+	if got := string(<-r.out); got != "b" {
+		t.Errorf("first out = %q, want %q", got, "b")
+	}
+	if got := string(<-r.out); got != "c" {
+		t.Errorf("second out = %q, want %q", got, "c")
+	}
+	if dropped := r.DroppedCount(); dropped != 0 {
+		t.Errorf("DroppedCount() = %d, want 0 (DropOldest doesn't count evictions as drops)", dropped)
+	}
+}
+
+func TestRingBufferDropNewestDiscardsIncomingOnOverflow(t *testing.T) {
+	r := newRingBuffer(1, DropNewest, 0)
+
+	r.Push([]byte("a"))
+	r.Push([]byte("b")) // buffer full; b is dropped
+
+	if got := string(<-r.out); got != "a" {
+		t.Errorf("out = %q, want %q", got, "a")
+	}
+	if dropped := r.DroppedCount(); dropped != 1 {
+		t.Errorf("DroppedCount() = %d, want 1", dropped)
+	}
+}
+
+func TestRingBufferBlockWithTimeoutAdmitsOnceRoomFrees(t *testing.T) {
+	r := newRingBuffer(1, BlockWithTimeout, time.Second)
+
+	r.Push([]byte("a"))
+
+	done := make(chan struct{})
+	go func() {
+		r.Push([]byte("b")) // blocks until "a" is drained below
+		close(done)
+	}()
+
+	if got := string(<-r.out); got != "a" {
+		t.Fatalf("first out = %q, want %q", got, "a")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Push did not unblock after room freed")
+	}
+
+	if got := string(<-r.out); got != "b" {
+		t.Errorf("second out = %q, want %q", got, "b")
+	}
+	if dropped := r.DroppedCount(); dropped != 0 {
+		t.Errorf("DroppedCount() = %d, want 0", dropped)
+	}
+}
+
+func TestRingBufferBlockWithTimeoutDropsAfterTimeoutExpires(t *testing.T) {
+	r := newRingBuffer(1, BlockWithTimeout, 10*time.Millisecond)
+
+	r.Push([]byte("a")) // fills the buffer; never drained
+	r.Push([]byte("b")) // should time out and be dropped
+
+	if dropped := r.DroppedCount(); dropped != 1 {
+		t.Errorf("DroppedCount() = %d, want 1", dropped)
+	}
+}
+
+func TestRingBufferCloseClosesOutChannel(t *testing.T) {
+	r := newRingBuffer(1, DropNewest, 0)
+	r.Close()
+
+	_, ok := <-r.out
+	if ok {
+		t.Error("reading from out after Close() = ok, want channel closed")
+	}
+}
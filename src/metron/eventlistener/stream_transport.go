@@ -0,0 +1,206 @@
+package eventlistener
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"sync"
+	"time"
+)
+
+// defaultMaxFrameSize bounds the length prefix streamTransport will honor
+// before allocating a frame buffer, so a malicious or misbehaving client
+// can't force a multi-gigabyte allocation with a single 4-byte prefix.
+const defaultMaxFrameSize = 1024 * 1024
+
+// defaultReadTimeout bounds how long readFrames will wait for a client's
+// header block or next length-prefixed frame before giving up on the
+// connection, so a client that opens a connection and never sends anything
+// can't pin a goroutine and a connections table entry indefinitely.
+const defaultReadTimeout = 5 * time.Minute
+
+// streamTransport implements Transport over a connection-oriented listener
+// (TCP or TLS). Each accepted connection is framed with a 4-byte
+// big-endian length prefix per message, matching the dropsonde/metron
+// stream client. Since a connection has no per-packet sender address, it
+// has no PacketConn and is meant to be paired with a no-op heartbeat
+// requester.
+//
+// When clientIPResolver is set (see WithClientIPResolver), each connection
+// is expected to open with an HTTP-style header block (terminated by a
+// blank line) before its framed messages, as written by a reverse proxy
+// fronting the listener; the resolved X-Real-Ip/X-Forwarded-For address
+// replaces the connection's remote address for every frame read from it.
+type streamTransport struct {
+	listenFunc       func(host string) (net.Listener, error)
+	clientIPResolver *ClientIPResolver
+	maxFrameSize     uint32
+	readTimeout      time.Duration
+
+	listener  net.Listener
+	messages  chan streamMessage
+	closed    chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+
+	connLock    sync.Mutex
+	connections map[net.Conn]struct{}
+}
+
+type streamMessage struct {
+	data []byte
+	addr net.Addr
+	err  error
+}
+
+func newTCPTransport() *streamTransport {
+	return &streamTransport{
+		listenFunc:   func(host string) (net.Listener, error) { return net.Listen("tcp", host) },
+		maxFrameSize: defaultMaxFrameSize,
+		readTimeout:  defaultReadTimeout,
+		messages:     make(chan streamMessage),
+		closed:       make(chan struct{}),
+		connections:  make(map[net.Conn]struct{}),
+	}
+}
+
+func newTLSTransport(tlsConfig *tls.Config) *streamTransport {
+	return &streamTransport{
+		listenFunc:   func(host string) (net.Listener, error) { return tls.Listen("tcp", host, tlsConfig) },
+		maxFrameSize: defaultMaxFrameSize,
+		readTimeout:  defaultReadTimeout,
+		messages:     make(chan streamMessage),
+		closed:       make(chan struct{}),
+		connections:  make(map[net.Conn]struct{}),
+	}
+}
+
+func (t *streamTransport) Listen(host string) error {
+	listener, err := t.listenFunc(host)
+	if err != nil {
+		return err
+	}
+	t.listener = listener
+	go t.accept()
+	return nil
+}
+
+func (t *streamTransport) accept() {
+	for {
+		connection, err := t.listener.Accept()
+		if err != nil {
+			select {
+			case <-t.closed:
+				return
+			default:
+				t.messages <- streamMessage{err: err}
+				return
+			}
+		}
+		t.trackConnection(connection)
+		go t.readFrames(connection)
+	}
+}
+
+func (t *streamTransport) trackConnection(connection net.Conn) {
+	t.connLock.Lock()
+	defer t.connLock.Unlock()
+	t.connections[connection] = struct{}{}
+}
+
+func (t *streamTransport) untrackConnection(connection net.Conn) {
+	t.connLock.Lock()
+	defer t.connLock.Unlock()
+	delete(t.connections, connection)
+}
+
+func (t *streamTransport) readFrames(connection net.Conn) {
+	defer connection.Close()
+	defer t.untrackConnection(connection)
+
+	var reader io.Reader = connection
+	addr := connection.RemoteAddr()
+
+	if t.clientIPResolver != nil {
+		connection.SetReadDeadline(time.Now().Add(t.readTimeout))
+		bufferedReader := bufio.NewReader(connection)
+		header, err := textproto.NewReader(bufferedReader).ReadMIMEHeader()
+		if err != nil {
+			return
+		}
+		addr = t.clientIPResolver.Resolve(connection.RemoteAddr(), header)
+		reader = bufferedReader
+	}
+
+	var length uint32
+	for {
+		connection.SetReadDeadline(time.Now().Add(t.readTimeout))
+		if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+			return
+		}
+		if length > t.maxFrameSize {
+			// A client sending a length prefix this large is either
+			// broken or hostile; drop its connection rather than
+			// allocating a buffer for it.
+			return
+		}
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(reader, frame); err != nil {
+			return
+		}
+
+		select {
+		case t.messages <- streamMessage{data: frame, addr: addr}:
+		case <-t.closed:
+			return
+		}
+	}
+}
+
+func (t *streamTransport) ReadMessage(buffer []byte) (int, net.Addr, error) {
+	message, ok := <-t.messages
+	if !ok {
+		return 0, nil, io.EOF
+	}
+	if message.err != nil {
+		return 0, nil, message.err
+	}
+	if len(message.data) > len(buffer) {
+		// readFrames already rejects any frame over maxFrameSize, and
+		// EventListener sizes its read buffer via MaxMessageSize, so this
+		// indicates the two have drifted out of sync. Report it rather
+		// than silently truncating the message.
+		return 0, message.addr, fmt.Errorf("eventlistener: frame of %d bytes exceeds read buffer of %d bytes", len(message.data), len(buffer))
+	}
+	return copy(buffer, message.data), message.addr, nil
+}
+
+func (t *streamTransport) MaxMessageSize() int {
+	return int(t.maxFrameSize)
+}
+
+func (t *streamTransport) PacketConn() net.PacketConn {
+	return nil
+}
+
+// Close shuts down the listener and every connection accepted from it. It
+// is safe to call more than once; only the first call has any effect, so a
+// caller that calls Stop twice (as EventListener.Stop can, e.g. if invoked
+// concurrently) doesn't panic on an already-closed t.closed channel.
+func (t *streamTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+		t.closeErr = t.listener.Close()
+
+		t.connLock.Lock()
+		defer t.connLock.Unlock()
+		for connection := range t.connections {
+			connection.Close()
+		}
+	})
+	return t.closeErr
+}
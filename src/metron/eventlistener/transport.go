@@ -0,0 +1,64 @@
+package eventlistener
+
+import "net"
+
+// maxUDPMessageSize is the largest theoretically possible UDP datagram.
+const maxUDPMessageSize = 65535
+
+// Transport abstracts the ingress mechanism an EventListener reads from, so
+// that UDP, TCP, TLS, and Unix datagram sockets can all feed the same
+// dataChannel and cooperate with the heartbeat requester.
+type Transport interface {
+	// Listen binds the transport to host and prepares it to be read from.
+	Listen(host string) error
+	// ReadMessage blocks until a single message is available, returning the
+	// number of bytes read and the address it arrived from. The address is
+	// nil only if the transport can't associate one with an individual
+	// message.
+	ReadMessage(buffer []byte) (int, net.Addr, error)
+	// MaxMessageSize returns the largest message this transport will ever
+	// hand to ReadMessage, so callers can size their read buffer without
+	// risking silent truncation.
+	MaxMessageSize() int
+	// PacketConn returns the underlying net.PacketConn for transports that
+	// support heartbeat correlation (UDP, Unix datagram), or nil for
+	// connection-oriented transports.
+	PacketConn() net.PacketConn
+	Close() error
+}
+
+type udpTransport struct {
+	readBufferSize int
+	connection     *net.UDPConn
+}
+
+func newUDPTransport(readBufferSize int) *udpTransport {
+	return &udpTransport{readBufferSize: readBufferSize}
+}
+
+func (t *udpTransport) Listen(host string) error {
+	connection, err := net.ListenPacket("udp", host)
+	if err != nil {
+		return err
+	}
+	udpConnection := connection.(*net.UDPConn)
+	udpConnection.SetReadBuffer(t.readBufferSize)
+	t.connection = udpConnection
+	return nil
+}
+
+func (t *udpTransport) ReadMessage(buffer []byte) (int, net.Addr, error) {
+	return t.connection.ReadFrom(buffer)
+}
+
+func (t *udpTransport) MaxMessageSize() int {
+	return maxUDPMessageSize
+}
+
+func (t *udpTransport) PacketConn() net.PacketConn {
+	return t.connection
+}
+
+func (t *udpTransport) Close() error {
+	return t.connection.Close()
+}
@@ -0,0 +1,48 @@
+package eventlistener
+
+import "net"
+
+// unixgramTransport listens on a Unix datagram socket. Like UDP, each
+// message carries its own sender address, so it still cooperates with the
+// heartbeat requester.
+type unixgramTransport struct {
+	readBufferSize int
+	connection     *net.UnixConn
+}
+
+func newUnixgramTransport(readBufferSize int) *unixgramTransport {
+	return &unixgramTransport{readBufferSize: readBufferSize}
+}
+
+func (t *unixgramTransport) Listen(host string) error {
+	addr, err := net.ResolveUnixAddr("unixgram", host)
+	if err != nil {
+		return err
+	}
+	connection, err := net.ListenUnixgram("unixgram", addr)
+	if err != nil {
+		return err
+	}
+	connection.SetReadBuffer(t.readBufferSize)
+	t.connection = connection
+	return nil
+}
+
+func (t *unixgramTransport) ReadMessage(buffer []byte) (int, net.Addr, error) {
+	return t.connection.ReadFrom(buffer)
+}
+
+// MaxMessageSize reports the UDP limit. A Unix datagram can be larger than
+// that; ReadMessage then returns only its first maxUDPMessageSize bytes,
+// with no error.
+func (t *unixgramTransport) MaxMessageSize() int {
+	return maxUDPMessageSize
+}
+
+func (t *unixgramTransport) PacketConn() net.PacketConn {
+	return t.connection
+}
+
+func (t *unixgramTransport) Close() error {
+	return t.connection.Close()
+}